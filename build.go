@@ -0,0 +1,157 @@
+package nsscacheindex
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// BuildFile builds a libnss-cache index at dstPath from the passwd-like text
+// database at srcPath, indexing the given 1-indexed column (validated as
+// numeric if numeric is set), and atomically replaces dstPath via a
+// dstPath+"~" temporary file and rename. The source is streamed through a
+// Builder bounded by maxMemory (0 selects DefaultMaxMemory), and the
+// temporary file is given srcPath's owner, group, and permission bits
+// before it replaces dstPath. It returns the number of rows indexed.
+//
+// BuildFile is safe to call repeatedly against the same srcPath/dstPath
+// pair, which is what daemon/watch mode does on every rebuild. Building
+// several indexes off of one srcPath is more efficient through BuildConfig,
+// which parses a shared source only once.
+func BuildFile(srcPath, dstPath string, column int, numeric bool, maxMemory int64) (rows int, err error) {
+	return BuildConfig(Config{Jobs: []Job{{Source: srcPath, Dest: dstPath, Column: column, Numeric: numeric}}}, maxMemory)
+}
+
+// BuildConfig builds every Job in cfg, parsing each distinct Source file
+// only once even when several Jobs share it, and returns the total number
+// of record lines scanned (blank and "#" comment lines are not counted;
+// each line is counted once per shared Source, not once per Job reading
+// it).
+//
+// A Job whose column fails validation on some line of its Source doesn't
+// affect sibling Jobs sharing that Source: each failing Job is dropped and
+// reported in the returned error (via errors.Join), but every Job that
+// validated cleanly is still built and written, exactly as if it had been
+// the only Job given.
+func BuildConfig(cfg Config, maxMemory int64) (rows int, err error) {
+	groups := make(map[string][]int, len(cfg.Jobs))
+	order := make([]string, 0, len(cfg.Jobs))
+	for i, job := range cfg.Jobs {
+		if _, ok := groups[job.Source]; !ok {
+			order = append(order, job.Source)
+		}
+		groups[job.Source] = append(groups[job.Source], i)
+	}
+
+	var errs []error
+	for _, source := range order {
+		n, err := buildGroup(source, cfg.Jobs, groups[source], maxMemory)
+		rows += n
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return rows, errors.Join(errs...)
+}
+
+// buildGroup builds the Jobs at the given indices into jobs, all of which
+// share the same Source file, parsing that file exactly once. A Job whose
+// column is invalid on some line is dropped (and reported in the returned
+// error) without affecting the other Jobs in the group.
+func buildGroup(source string, jobs []Job, indices []int, maxMemory int64) (int, error) {
+	src, err := os.OpenFile(source, os.O_RDONLY, 0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open source file %s: %w", source, err)
+	}
+	defer src.Close()
+
+	fi, err := src.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat source file %s: %w", source, err)
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, fmt.Errorf("source file %s's io/fs.FileInfo object was backed by %T, not *syscall.Stat_t", source, fi.Sys())
+	}
+
+	targets := make([]Target, len(indices))
+	builders := make([]*Builder, len(indices))
+	failed := make([]error, len(indices))
+	for i, idx := range indices {
+		targets[i] = Target{Column: jobs[idx].Column, Numeric: jobs[idx].Numeric}
+		builders[i] = NewBuilder(maxMemory)
+		defer builders[i].Close()
+	}
+
+	var rows int
+	err = ScanMulti(src, targets, func(target int, e Entry, verr error) error {
+		if target == 0 {
+			rows++
+		}
+		if failed[target] != nil {
+			return nil
+		}
+		if verr != nil {
+			failed[target] = fmt.Errorf("job for dest %s: %w", jobs[indices[target]].Dest, verr)
+			return nil
+		}
+		if err := builders[target].Add(e); err != nil {
+			failed[target] = fmt.Errorf("job for dest %s: %w", jobs[indices[target]].Dest, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse source file %s: %w", source, err)
+	}
+
+	var errs []error
+	for i, idx := range indices {
+		if failed[i] != nil {
+			errs = append(errs, failed[i])
+			continue
+		}
+		if err := writeIndexFile(builders[i], jobs[idx].Dest, st.Uid, st.Gid, os.FileMode(st.Mode&07777)); err != nil {
+			errs = append(errs, fmt.Errorf("job for dest %s: %w", jobs[idx].Dest, err))
+		}
+	}
+	return rows, errors.Join(errs...)
+}
+
+// writeIndexFile sorts builder's entries and atomically replaces dstPath
+// with the result, via a dstPath+"~" temporary file given the specified
+// owner, group, and permission bits.
+func writeIndexFile(builder *Builder, dstPath string, uid, gid uint32, mode os.FileMode) error {
+	tempPath := dstPath + "~"
+	dst, err := os.OpenFile(tempPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open temporary file %s: %w", tempPath, err)
+	}
+
+	needCleanup := true
+	defer func() {
+		if needCleanup {
+			_ = dst.Close()
+			_ = os.Remove(tempPath)
+		}
+	}()
+
+	if err := builder.WriteIndex(dst); err != nil {
+		return fmt.Errorf("failed to write temporary file %s: %w", tempPath, err)
+	}
+	if err := dst.Chown(int(uid), int(gid)); err != nil {
+		return fmt.Errorf("failed to chown temporary file %s: %w", tempPath, err)
+	}
+	if err := dst.Chmod(mode); err != nil {
+		return fmt.Errorf("failed to chmod temporary file %s: %w", tempPath, err)
+	}
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file %s: %w", tempPath, err)
+	}
+	needCleanup = false
+
+	if err := os.Rename(tempPath, dstPath); err != nil {
+		return fmt.Errorf("failed to replace %s with temporary file %s: %w", dstPath, tempPath, err)
+	}
+	return nil
+}
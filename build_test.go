@@ -0,0 +1,62 @@
+package nsscacheindex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const buildTestSource = "root:x:0:0::/root:/bin/bash\n" +
+	"daemon:x:1:1::/sbin:/usr/sbin/nologin\n" +
+	"games:x:5:60::/usr/games:/usr/sbin/nologin\n"
+
+func TestBuildFile(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "passwd")
+	dstPath := filepath.Join(dir, "passwd.byname")
+	if err := os.WriteFile(srcPath, []byte(buildTestSource), 0o644); err != nil {
+		t.Fatalf("write source fixture: %v", err)
+	}
+
+	rows, err := BuildFile(srcPath, dstPath, 1, false, 0)
+	if err != nil {
+		t.Fatalf("BuildFile: %v", err)
+	}
+	if rows != 3 {
+		t.Errorf("rows = %d, want 3", rows)
+	}
+
+	if _, err := VerifyIndex(dstPath, srcPath, 1, false); err != nil {
+		t.Errorf("VerifyIndex on BuildFile's output: %v", err)
+	}
+}
+
+func TestBuildConfigSharesSourceAcrossJobs(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "passwd")
+	if err := os.WriteFile(srcPath, []byte(buildTestSource), 0o644); err != nil {
+		t.Fatalf("write source fixture: %v", err)
+	}
+	nameDest := filepath.Join(dir, "passwd.byname")
+	uidDest := filepath.Join(dir, "passwd.byuid")
+
+	cfg := Config{Jobs: []Job{
+		{Source: srcPath, Dest: nameDest, Column: 1, Numeric: false},
+		{Source: srcPath, Dest: uidDest, Column: 3, Numeric: true},
+	}}
+
+	rows, err := BuildConfig(cfg, 0)
+	if err != nil {
+		t.Fatalf("BuildConfig: %v", err)
+	}
+	if rows != 3 {
+		t.Errorf("rows = %d, want 3", rows)
+	}
+
+	if _, err := VerifyIndex(nameDest, srcPath, 1, false); err != nil {
+		t.Errorf("VerifyIndex on name index: %v", err)
+	}
+	if _, err := VerifyIndex(uidDest, srcPath, 3, true); err != nil {
+		t.Errorf("VerifyIndex on uid index: %v", err)
+	}
+}
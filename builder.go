@@ -0,0 +1,221 @@
+package nsscacheindex
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// DefaultMaxMemory is the in-memory entry budget a Builder uses when
+// NewBuilder is given a maxMemory of 0.
+const DefaultMaxMemory = 64 << 20 // 64 MiB
+
+// entryOverhead is a rough per-Entry estimate of Go runtime/slice overhead on
+// top of the Key string's bytes, used to approximate a Builder's resident
+// memory against its budget without reflecting on every entry.
+const entryOverhead = 48
+
+// Builder accumulates Entry values with a bounded in-memory footprint. Once
+// the configured --max-memory budget is exceeded, the buffered entries are
+// sorted and spilled to a temporary "run" file, and WriteIndex performs a
+// k-way merge of every run (plus whatever remains buffered) to produce the
+// final sorted index. This keeps peak RSS bounded regardless of how many
+// entries are added.
+type Builder struct {
+	maxMemory int64
+
+	current      []Entry
+	currentBytes int64
+
+	runs               []string
+	longestEntryLength int
+}
+
+// NewBuilder returns a Builder that buffers up to maxMemory bytes of entries
+// in memory before spilling a sorted run to a temporary file. A maxMemory of
+// 0 or less selects DefaultMaxMemory.
+func NewBuilder(maxMemory int64) *Builder {
+	if maxMemory <= 0 {
+		maxMemory = DefaultMaxMemory
+	}
+	return &Builder{maxMemory: maxMemory, longestEntryLength: 2}
+}
+
+// Add appends entries to the Builder, spilling a sorted run to disk if the
+// --max-memory budget is exceeded.
+func (b *Builder) Add(entries ...Entry) error {
+	for _, e := range entries {
+		if el := entryLength(e); el > b.longestEntryLength {
+			b.longestEntryLength = el
+		}
+
+		b.current = append(b.current, e)
+		b.currentBytes += int64(len(e.Key)) + entryOverhead
+		if b.currentBytes >= b.maxMemory {
+			if err := b.flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// AddFromReader streams r through Scan, adding every entry found in the
+// given 1-indexed column. It returns the number of entries added.
+func (b *Builder) AddFromReader(r io.Reader, column int, numeric bool) (int, error) {
+	var n int
+	err := Scan(r, column, numeric, func(e Entry) error {
+		n++
+		return b.Add(e)
+	})
+	return n, err
+}
+
+// flush sorts the buffered entries and spills them to a new temporary run
+// file, resetting the in-memory buffer.
+func (b *Builder) flush() error {
+	if len(b.current) == 0 {
+		return nil
+	}
+
+	sort.Slice(b.current, func(i, j int) bool { return b.current[i].Key < b.current[j].Key })
+
+	f, err := os.CreateTemp("", "nsscacheindex-run-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary run file: %w", err)
+	}
+	defer f.Close()
+
+	bw := bufio.NewWriter(f)
+	enc := gob.NewEncoder(bw)
+	for _, e := range b.current {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("failed to write temporary run file %s: %w", f.Name(), err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write temporary run file %s: %w", f.Name(), err)
+	}
+
+	b.runs = append(b.runs, f.Name())
+	b.current = b.current[:0]
+	b.currentBytes = 0
+	return nil
+}
+
+// WriteIndex sorts the accumulated entries, merging any runs already
+// spilled to disk with whatever remains buffered in memory, and writes the
+// result to w in libnss-cache's fixed-width format. It does not remove the
+// temporary run files itself; callers should defer Close to clean those up
+// whether or not WriteIndex is ever reached.
+func (b *Builder) WriteIndex(w io.Writer) error {
+	if len(b.runs) == 0 {
+		sort.Slice(b.current, func(i, j int) bool { return b.current[i].Key < b.current[j].Key })
+		return writeEntries(w, b.current, b.longestEntryLength)
+	}
+
+	if err := b.flush(); err != nil {
+		return err
+	}
+
+	return b.mergeRuns(w)
+}
+
+// Close removes any temporary run files the Builder has spilled to disk.
+// Callers should defer Close immediately after NewBuilder, so that runs
+// are cleaned up even if Add/AddFromReader returns an error before
+// WriteIndex is ever called.
+func (b *Builder) Close() error {
+	var firstErr error
+	for _, name := range b.runs {
+		if err := os.Remove(name); err != nil && !os.IsNotExist(err) && firstErr == nil {
+			firstErr = err
+		}
+	}
+	b.runs = nil
+	return firstErr
+}
+
+// runCursor is one spilled run file's decode position within the k-way
+// merge performed by mergeRuns.
+type runCursor struct {
+	name string
+	dec  *gob.Decoder
+}
+
+// heapItem is one candidate entry in the k-way merge's min-heap, tagged
+// with the index of the runCursor it came from so mergeRuns can pull the
+// cursor's next entry once this one is popped.
+type heapItem struct {
+	entry Entry
+	run   int
+}
+
+type entryHeap []heapItem
+
+func (h entryHeap) Len() int            { return len(h) }
+func (h entryHeap) Less(i, j int) bool  { return h[i].entry.Key < h[j].entry.Key }
+func (h entryHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *entryHeap) Push(x interface{}) { *h = append(*h, x.(heapItem)) }
+func (h *entryHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// mergeRuns k-way merges b.runs through a min-heap and writes the result to
+// w, buffering only a small, constant number of entries at a time.
+func (b *Builder) mergeRuns(w io.Writer) error {
+	cursors := make([]runCursor, len(b.runs))
+	h := make(entryHeap, 0, len(b.runs))
+	for i, name := range b.runs {
+		f, err := os.Open(name)
+		if err != nil {
+			return fmt.Errorf("failed to open temporary run file %s: %w", name, err)
+		}
+		defer f.Close()
+
+		cursors[i] = runCursor{name: name, dec: gob.NewDecoder(bufio.NewReader(f))}
+
+		var e Entry
+		if err := cursors[i].dec.Decode(&e); err != nil {
+			if err == io.EOF {
+				continue
+			}
+			return fmt.Errorf("failed to read temporary run file %s: %w", name, err)
+		}
+		h = append(h, heapItem{entry: e, run: i})
+	}
+	heap.Init(&h)
+
+	const batchSize = 256
+	batch := make([]Entry, 0, batchSize)
+	for h.Len() > 0 {
+		item := heap.Pop(&h).(heapItem)
+		batch = append(batch, item.entry)
+		if len(batch) == batchSize {
+			if err := writeEntries(w, batch, b.longestEntryLength); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+
+		var next Entry
+		cursor := cursors[item.run]
+		switch err := cursor.dec.Decode(&next); err {
+		case nil:
+			heap.Push(&h, heapItem{entry: next, run: item.run})
+		case io.EOF:
+		default:
+			return fmt.Errorf("failed to read temporary run file %s: %w", cursor.name, err)
+		}
+	}
+
+	return writeEntries(w, batch, b.longestEntryLength)
+}
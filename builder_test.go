@@ -0,0 +1,96 @@
+package nsscacheindex
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"testing"
+)
+
+// decodeIndex parses libnss-cache's on-disk format back into Entry values,
+// for asserting on what Builder.WriteIndex actually wrote.
+func decodeIndex(t *testing.T, data []byte) []Entry {
+	t.Helper()
+	if len(data) == 0 {
+		return nil
+	}
+	if data[len(data)-1] != '\n' {
+		t.Fatalf("index does not end with a trailing LF")
+	}
+
+	var entries []Entry
+	for _, rec := range bytes.Split(data[:len(data)-1], []byte{'\n'}) {
+		fields := bytes.SplitN(rec, []byte{0}, 3)
+		if len(fields) < 2 {
+			t.Fatalf("record %q is missing its NUL-delimited offset field", rec)
+		}
+		var offset int64
+		if _, err := fmt.Sscanf(string(fields[1]), "%d", &offset); err != nil {
+			t.Fatalf("record %q has invalid offset: %v", rec, err)
+		}
+		entries = append(entries, Entry{Key: string(fields[0]), Offset: offset})
+	}
+	return entries
+}
+
+func TestBuilderExternalMergeSort(t *testing.T) {
+	// A 1-byte --max-memory budget forces every Add to spill its own run,
+	// exercising the k-way merge across many runs rather than the
+	// single-batch in-memory path.
+	b := NewBuilder(1)
+	defer b.Close()
+
+	keys := []string{"zebra", "mango", "apple", "fig", "date", "banana", "cherry"}
+	want := make([]Entry, len(keys))
+	for i, k := range keys {
+		want[i] = Entry{Key: k, Offset: int64(i * 10)}
+		if err := b.Add(want[i]); err != nil {
+			t.Fatalf("Add(%q): %v", k, err)
+		}
+	}
+
+	if len(b.runs) < 2 {
+		t.Fatalf("expected multiple spilled runs with --max-memory 1, got %d", len(b.runs))
+	}
+
+	var buf bytes.Buffer
+	if err := b.WriteIndex(&buf); err != nil {
+		t.Fatalf("WriteIndex: %v", err)
+	}
+
+	got := decodeIndex(t, buf.Bytes())
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(got), len(want))
+	}
+
+	sort.Slice(want, func(i, j int) bool { return want[i].Key < want[j].Key })
+	for i, e := range got {
+		if i > 0 && e.Key < got[i-1].Key {
+			t.Fatalf("entry %d (%q) is not sorted after entry %d (%q)", i, e.Key, i-1, got[i-1].Key)
+		}
+		if e != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, e, want[i])
+		}
+	}
+}
+
+func TestBuilderClosePreventsLeakedRuns(t *testing.T) {
+	b := NewBuilder(1)
+	if err := b.Add(Entry{Key: "a", Offset: 0}, Entry{Key: "b", Offset: 1}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if len(b.runs) == 0 {
+		t.Fatalf("expected at least one spilled run")
+	}
+	runs := append([]string(nil), b.runs...)
+
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for _, name := range runs {
+		if _, err := os.Stat(name); err == nil {
+			t.Errorf("run file %s still exists after Close", name)
+		}
+	}
+}
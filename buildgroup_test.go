@@ -0,0 +1,49 @@
+package nsscacheindex
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestBuildConfigIsolatesFailingJobInSharedGroup covers the same scenario as
+// TestScanMultiIsolatesPerTargetValidation one layer up: two Jobs sharing a
+// Source, where only one Job's column is invalid on some row. Building the
+// name index should succeed even though the uid index can't be built.
+func TestBuildConfigIsolatesFailingJobInSharedGroup(t *testing.T) {
+	const source = "root:x:0:0::/root:/bin/bash\n" +
+		"bogus:x:nope:0::/home/bogus:/bin/sh\n" +
+		"daemon:x:1:1::/sbin:/usr/sbin/nologin\n"
+
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "passwd")
+	if err := os.WriteFile(srcPath, []byte(source), 0o644); err != nil {
+		t.Fatalf("write source fixture: %v", err)
+	}
+	nameDest := filepath.Join(dir, "passwd.byname")
+	uidDest := filepath.Join(dir, "passwd.byuid")
+
+	cfg := Config{Jobs: []Job{
+		{Source: srcPath, Dest: nameDest, Column: 1, Numeric: false},
+		{Source: srcPath, Dest: uidDest, Column: 3, Numeric: true},
+	}}
+
+	rows, err := BuildConfig(cfg, 0)
+	if err == nil {
+		t.Fatal("BuildConfig succeeded despite the uid job's malformed row")
+	}
+	if !strings.Contains(err.Error(), uidDest) {
+		t.Errorf("error = %v, want mention of the failing job's dest %s", err, uidDest)
+	}
+	if rows != 3 {
+		t.Errorf("rows = %d, want 3", rows)
+	}
+
+	if _, err := os.Stat(nameDest); err != nil {
+		t.Errorf("name index was not written despite validating cleanly: %v", err)
+	}
+	if _, err := os.Stat(uidDest); err == nil {
+		t.Errorf("uid index was written despite its job failing")
+	}
+}
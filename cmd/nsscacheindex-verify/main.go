@@ -0,0 +1,75 @@
+// Command nsscacheindex-verify validates a libnss-cache index file
+// produced by nsscacheindex against the source text database it was built
+// from, to detect bit rot or accidental hand-edits before nscd/nsscache
+// consumers trip over a malformed index.
+package main
+
+import (
+	"time"
+
+	getopt "github.com/pborman/getopt/v2"
+	"github.com/rs/zerolog"
+
+	"github.com/chronos-tachyon/nsscacheindex"
+	"github.com/chronos-tachyon/nsscacheindex/internal/logsetup"
+)
+
+var (
+	Logger zerolog.Logger
+
+	flagLogJSON       bool
+	flagLogFile       string
+	flagLogMaxSizeMB  int
+	flagLogMaxAgeDays int
+	flagLogMaxBackups int
+	flagLogCompress   bool
+	flagSrcFile       string
+	flagIdxFile       string
+	flagColumn        int
+	flagNumeric       bool
+)
+
+func init() {
+	getopt.SetParameters("")
+	getopt.FlagLong(&flagLogJSON, "log-json", 'J', "log JSON to stderr").SetFlag()
+	getopt.FlagLong(&flagLogFile, "log-file", 0, "write structured JSON logs to this file (with rotation) instead of stderr")
+	getopt.FlagLong(&flagLogMaxSizeMB, "log-max-size-mb", 0, "rotate --log-file once it reaches this many megabytes")
+	getopt.FlagLong(&flagLogMaxAgeDays, "log-max-age-days", 0, "remove rotated --log-file backups older than this many days")
+	getopt.FlagLong(&flagLogMaxBackups, "log-max-backups", 0, "keep at most this many rotated --log-file backups")
+	getopt.FlagLong(&flagLogCompress, "log-compress", 0, "gzip rotated --log-file backups").SetFlag()
+	getopt.FlagLong(&flagSrcFile, "source-file", 's', "passwd-like file the index was built from").Mandatory()
+	getopt.FlagLong(&flagIdxFile, "index-file", 'i', "libnss-cache index file to validate").Mandatory()
+	getopt.FlagLong(&flagColumn, "column", 'c', "column in --source-file the index was built from").Mandatory()
+	getopt.FlagLong(&flagNumeric, "numeric", 'n', "set if the specified column is numeric").SetFlag()
+}
+
+func main() {
+	getopt.Parse()
+
+	Logger = logsetup.New(logsetup.Config{
+		JSON:       flagLogJSON,
+		FilePath:   flagLogFile,
+		MaxSizeMB:  flagLogMaxSizeMB,
+		MaxAgeDays: flagLogMaxAgeDays,
+		MaxBackups: flagLogMaxBackups,
+		Compress:   flagLogCompress,
+	})
+
+	start := time.Now()
+	rows, err := nsscacheindex.VerifyIndex(flagIdxFile, flagSrcFile, flagColumn, flagNumeric)
+	if err != nil {
+		Logger.Fatal().
+			Str("source-file", flagSrcFile).
+			Str("index-file", flagIdxFile).
+			Dur("duration", time.Since(start)).
+			Err(err).
+			Msg("index verification failed")
+	}
+
+	Logger.Info().
+		Str("source-file", flagSrcFile).
+		Str("index-file", flagIdxFile).
+		Dur("duration", time.Since(start)).
+		Int("rows", rows).
+		Msg("index verified successfully")
+}
@@ -0,0 +1,133 @@
+// Command nsscacheindex builds libnss-cache binary index files from
+// passwd-like text databases (passwd, group, shadow, netgroup, automount,
+// ...).
+package main
+
+import (
+	"os"
+	"time"
+
+	getopt "github.com/pborman/getopt/v2"
+	"github.com/rs/zerolog"
+
+	"github.com/chronos-tachyon/nsscacheindex"
+	"github.com/chronos-tachyon/nsscacheindex/internal/logsetup"
+)
+
+var (
+	Logger zerolog.Logger
+
+	flagLogJSON       bool
+	flagLogFile       string
+	flagLogMaxSizeMB  int
+	flagLogMaxAgeDays int
+	flagLogMaxBackups int
+	flagLogCompress   bool
+	flagConfigFile    string
+	flagSrcFile       string
+	flagDstFile       string
+	flagColumn        int
+	flagNumeric       bool
+	flagMaxMemory     int64
+	flagWatch         bool
+	flagDebounce      time.Duration
+)
+
+func init() {
+	getopt.SetParameters("")
+	getopt.FlagLong(&flagLogJSON, "log-json", 'J', "log JSON to stderr").SetFlag()
+	getopt.FlagLong(&flagLogFile, "log-file", 0, "write structured JSON logs to this file (with rotation) instead of stderr")
+	getopt.FlagLong(&flagLogMaxSizeMB, "log-max-size-mb", 0, "rotate --log-file once it reaches this many megabytes")
+	getopt.FlagLong(&flagLogMaxAgeDays, "log-max-age-days", 0, "remove rotated --log-file backups older than this many days")
+	getopt.FlagLong(&flagLogMaxBackups, "log-max-backups", 0, "keep at most this many rotated --log-file backups")
+	getopt.FlagLong(&flagLogCompress, "log-compress", 0, "gzip rotated --log-file backups").SetFlag()
+	getopt.FlagLong(&flagConfigFile, "config", 0, "YAML or JSON file listing index jobs to build; overrides --source-file/--dest-file/--column/--numeric")
+	getopt.FlagLong(&flagSrcFile, "source-file", 's', "passwd-like file to read from")
+	getopt.FlagLong(&flagDstFile, "dest-file", 'd', "libnss-cache index file to create")
+	getopt.FlagLong(&flagColumn, "column", 'c', "column in --source-file to index")
+	getopt.FlagLong(&flagNumeric, "numeric", 'n', "set if the specified column is numeric").SetFlag()
+	getopt.FlagLong(&flagMaxMemory, "max-memory", 'm', "bytes of entries to buffer before spilling a sorted run to disk (0 selects the default)")
+	getopt.FlagLong(&flagWatch, "watch", 'w', "keep running and rebuild whenever a source file changes (not supported together with --config)").SetFlag()
+	getopt.FlagLong(&flagDebounce, "debounce", 0, "time to wait for source file writes to settle before rebuilding in --watch mode")
+}
+
+func loadConfig() nsscacheindex.Config {
+	if flagConfigFile != "" {
+		f, err := os.Open(flagConfigFile)
+		if err != nil {
+			Logger.Fatal().
+				Str("config-file", flagConfigFile).
+				Err(err).
+				Msg("failed to open config file")
+		}
+		defer f.Close()
+
+		cfg, err := nsscacheindex.LoadConfig(f)
+		if err != nil {
+			Logger.Fatal().
+				Str("config-file", flagConfigFile).
+				Err(err).
+				Msg("failed to parse config file")
+		}
+		if flagWatch {
+			Logger.Fatal().Msg("--watch is not supported together with --config")
+		}
+		return cfg
+	}
+
+	if flagSrcFile == "" || flagDstFile == "" || flagColumn == 0 {
+		Logger.Fatal().Msg("--source-file, --dest-file, and --column are required unless --config is given")
+	}
+	return nsscacheindex.Config{
+		Jobs: []nsscacheindex.Job{
+			{Source: flagSrcFile, Dest: flagDstFile, Column: flagColumn, Numeric: flagNumeric},
+		},
+	}
+}
+
+func main() {
+	getopt.Parse()
+
+	Logger = logsetup.New(logsetup.Config{
+		JSON:       flagLogJSON,
+		FilePath:   flagLogFile,
+		MaxSizeMB:  flagLogMaxSizeMB,
+		MaxAgeDays: flagLogMaxAgeDays,
+		MaxBackups: flagLogMaxBackups,
+		Compress:   flagLogCompress,
+	})
+
+	if flagDebounce <= 0 {
+		flagDebounce = 500 * time.Millisecond
+	}
+
+	cfg := loadConfig()
+
+	build := func() {
+		start := time.Now()
+		rows, err := nsscacheindex.BuildConfig(cfg, flagMaxMemory)
+		if err != nil {
+			Logger.Fatal().
+				Err(err).
+				Msg("failed to build index")
+		}
+		Logger.Info().
+			Dur("duration", time.Since(start)).
+			Int("rows", rows).
+			Int("jobs", len(cfg.Jobs)).
+			Msg("built index")
+	}
+
+	build()
+
+	if !flagWatch {
+		return
+	}
+
+	if err := watch(Logger, flagSrcFile, flagDstFile, flagColumn, flagNumeric, flagMaxMemory, flagDebounce); err != nil {
+		Logger.Fatal().
+			Str("source-file", flagSrcFile).
+			Err(err).
+			Msg("watch mode failed")
+	}
+}
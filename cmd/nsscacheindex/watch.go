@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+
+	"github.com/chronos-tachyon/nsscacheindex"
+)
+
+// statFallbackInterval is how often watch polls srcFile's metadata directly,
+// as a backstop for networked filesystems where fsnotify doesn't fire.
+const statFallbackInterval = 5 * time.Second
+
+// sourceStat is the subset of os.FileInfo that watch compares across polls
+// to decide whether srcFile has actually changed, rather than trusting
+// every fsnotify event or stat tick to mean new content.
+type sourceStat struct {
+	mtime time.Time
+	size  int64
+	inode uint64
+}
+
+func statSource(path string) (sourceStat, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return sourceStat{}, err
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return sourceStat{}, fmt.Errorf("source file %s's io/fs.FileInfo object was backed by %T, not *syscall.Stat_t", path, fi.Sys())
+	}
+	return sourceStat{mtime: fi.ModTime(), size: fi.Size(), inode: st.Ino}, nil
+}
+
+// watch keeps rebuilding dstFile from srcFile as srcFile changes, until the
+// filesystem watcher fails unrecoverably. fsnotify drives rebuilds on
+// filesystems where inotify/kqueue events fire, with a periodic stat-based
+// fallback covering networked filesystems where they don't. fsnotify events
+// are debounced so a burst of writes triggers one rebuild, not many; the
+// stat fallback is not debounced, and rebuilds on the next tick after
+// srcFile's mtime, size, or inode differs from the last successful build.
+func watch(logger zerolog.Logger, srcFile, dstFile string, column int, numeric bool, maxMemory int64, debounce time.Duration) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create filesystem watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	dir := filepath.Dir(srcFile)
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch directory %s: %w", dir, err)
+	}
+
+	last, _ := statSource(srcFile)
+
+	rebuildIfChanged := func() {
+		cur, statErr := statSource(srcFile)
+		if statErr == nil && cur == last {
+			return
+		}
+
+		start := time.Now()
+		rows, buildErr := nsscacheindex.BuildFile(srcFile, dstFile, column, numeric, maxMemory)
+		ev := logger.Info()
+		if buildErr != nil {
+			ev = logger.Error().Err(buildErr)
+		}
+		ev.Dur("duration", time.Since(start)).
+			Int("rows", rows).
+			Bool("success", buildErr == nil).
+			Msg("rebuilt index")
+
+		if buildErr == nil && statErr == nil {
+			last = cur
+		}
+	}
+
+	timer := time.NewTimer(debounce)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	ticker := time.NewTicker(statFallbackInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(srcFile) {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			timer.Reset(debounce)
+
+		case watchErr, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			logger.Error().Err(watchErr).Msg("filesystem watcher error")
+
+		case <-timer.C:
+			rebuildIfChanged()
+
+		case <-ticker.C:
+			rebuildIfChanged()
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package nsscacheindex
+
+import (
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Job describes one index to build: Column of Source (numeric or not) is
+// indexed and written to Dest.
+type Job struct {
+	Source  string `json:"source" yaml:"source"`
+	Dest    string `json:"dest" yaml:"dest"`
+	Column  int    `json:"column" yaml:"column"`
+	Numeric bool   `json:"numeric" yaml:"numeric"`
+}
+
+// Config is a list of index-building Jobs, as loaded from a --config file.
+type Config struct {
+	Jobs []Job `json:"jobs" yaml:"jobs"`
+}
+
+// LoadConfig parses a Config from r as YAML, which is a superset of JSON,
+// so plain JSON config files are accepted too.
+func LoadConfig(r io.Reader) (Config, error) {
+	var cfg Config
+	if err := yaml.NewDecoder(r).Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,57 @@
+package nsscacheindex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigYAML(t *testing.T) {
+	const doc = `
+jobs:
+  - source: /etc/passwd
+    dest: /var/lib/nsscacheindex/passwd.byname
+    column: 1
+  - source: /etc/passwd
+    dest: /var/lib/nsscacheindex/passwd.byuid
+    column: 3
+    numeric: true
+`
+	cfg, err := LoadConfig(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	want := Config{Jobs: []Job{
+		{Source: "/etc/passwd", Dest: "/var/lib/nsscacheindex/passwd.byname", Column: 1, Numeric: false},
+		{Source: "/etc/passwd", Dest: "/var/lib/nsscacheindex/passwd.byuid", Column: 3, Numeric: true},
+	}}
+	if len(cfg.Jobs) != len(want.Jobs) {
+		t.Fatalf("got %d jobs, want %d: %+v", len(cfg.Jobs), len(want.Jobs), cfg.Jobs)
+	}
+	for i, job := range cfg.Jobs {
+		if job != want.Jobs[i] {
+			t.Errorf("job %d = %+v, want %+v", i, job, want.Jobs[i])
+		}
+	}
+}
+
+func TestLoadConfigJSON(t *testing.T) {
+	const doc = `{"jobs": [{"source": "/etc/group", "dest": "/var/lib/nsscacheindex/group.byname", "column": 1}]}`
+
+	cfg, err := LoadConfig(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	want := Job{Source: "/etc/group", Dest: "/var/lib/nsscacheindex/group.byname", Column: 1}
+	if len(cfg.Jobs) != 1 || cfg.Jobs[0] != want {
+		t.Fatalf("got %+v, want one job %+v", cfg.Jobs, want)
+	}
+}
+
+func TestLoadConfigRejectsMalformedYAML(t *testing.T) {
+	_, err := LoadConfig(strings.NewReader("jobs: [this is not valid"))
+	if err == nil {
+		t.Fatal("LoadConfig succeeded on malformed input")
+	}
+}
@@ -0,0 +1,9 @@
+package nsscacheindex
+
+// Entry is a single key/offset pair destined for a libnss-cache index: Key is
+// the value of the indexed column, and Offset is the byte offset of the start
+// of the corresponding line within the source file.
+type Entry struct {
+	Key    string
+	Offset int64
+}
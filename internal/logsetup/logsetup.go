@@ -0,0 +1,65 @@
+// Package logsetup builds the zerolog.Logger shared by nsscacheindex's
+// commands, so each one configures logging identically instead of
+// reimplementing it.
+package logsetup
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Config selects where and how a command's Logger writes.
+type Config struct {
+	// JSON logs JSON to stderr instead of a human-readable console
+	// format. Ignored when FilePath is set, since file output is always
+	// JSON.
+	JSON bool
+
+	// FilePath, if non-empty, routes log output through a rotating
+	// lumberjack.Logger at this path instead of stderr.
+	FilePath string
+
+	// MaxSizeMB is the size in megabytes a log file is allowed to reach
+	// before it's rotated.
+	MaxSizeMB int
+
+	// MaxAgeDays is the maximum number of days to retain old rotated log
+	// files.
+	MaxAgeDays int
+
+	// MaxBackups is the maximum number of old rotated log files to
+	// retain.
+	MaxBackups int
+
+	// Compress gzips rotated log files once they age out.
+	Compress bool
+}
+
+// New builds a Logger per cfg and configures zerolog's global time/duration
+// formatting, which is otherwise left at its zero value.
+func New(cfg Config) zerolog.Logger {
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+	zerolog.DurationFieldUnit = time.Second
+	zerolog.DurationFieldInteger = false
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+
+	var out io.Writer = zerolog.ConsoleWriter{Out: os.Stderr}
+	switch {
+	case cfg.FilePath != "":
+		out = &lumberjack.Logger{
+			Filename:   cfg.FilePath,
+			MaxSize:    cfg.MaxSizeMB,
+			MaxAge:     cfg.MaxAgeDays,
+			MaxBackups: cfg.MaxBackups,
+			Compress:   cfg.Compress,
+		}
+	case cfg.JSON:
+		out = os.Stderr
+	}
+
+	return zerolog.New(out).Level(zerolog.InfoLevel)
+}
@@ -0,0 +1,23 @@
+package nsscacheindex
+
+import "io"
+
+// Parse reads a passwd-like text database from r and returns the key/offset
+// pairs found in the given 1-indexed column. If numeric is set, the column is
+// validated as a non-negative decimal identifier rather than a user/group
+// name. Blank lines and lines beginning with "#" are skipped.
+//
+// Parse buffers every entry in memory; callers working with very large
+// source files should drive Scan directly, or feed a Builder via
+// Builder.AddFromReader, instead.
+func Parse(r io.Reader, column int, numeric bool) ([]Entry, error) {
+	var entries []Entry
+	err := Scan(r, column, numeric, func(e Entry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,38 @@
+package nsscacheindex
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	const source = "# comment\n" +
+		"\n" +
+		"root:x:0:0::/root:/bin/bash\n" +
+		"daemon:x:1:1::/sbin:/usr/sbin/nologin\n"
+
+	got, err := Parse(strings.NewReader(source), 1, false)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	want := []Entry{
+		{Key: "root", Offset: int64(len("# comment\n\n"))},
+		{Key: "daemon", Offset: int64(len("# comment\n\nroot:x:0:0::/root:/bin/bash\n"))},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(got), len(want), got)
+	}
+	for i, e := range got {
+		if e != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, e, want[i])
+		}
+	}
+}
+
+func TestParseRejectsInvalidColumn(t *testing.T) {
+	_, err := Parse(strings.NewReader("root:x:notanumber:0::/root:/bin/bash\n"), 3, true)
+	if err == nil {
+		t.Fatal("Parse succeeded on a non-numeric uid column")
+	}
+}
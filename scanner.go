@@ -0,0 +1,105 @@
+package nsscacheindex
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var (
+	reName = regexp.MustCompile(`^[A-Za-z_][0-9A-Za-z]+(?:[_.-][0-9A-Za-z]+)*$`)
+	reID   = regexp.MustCompile(`^(?:0|[1-9][0-9]*)$`)
+)
+
+// Target describes one column to extract during a ScanMulti pass: Column is
+// 1-indexed, and Numeric selects identifier validation over name
+// validation, exactly as for Scan.
+type Target struct {
+	Column  int
+	Numeric bool
+}
+
+// Scan streams a passwd-like text database from r, calling fn with the
+// key/offset pair found in the given 1-indexed column of each record line.
+// Blank lines and lines beginning with "#" are skipped. Unlike Parse, Scan
+// never buffers the whole of r in memory: it reads through a bufio.Reader
+// and tracks the current byte offset itself, so it is safe to use on
+// arbitrarily large source files.
+//
+// Scan aborts on the first invalid row, same as fn returning an error.
+func Scan(r io.Reader, column int, numeric bool, fn func(Entry) error) error {
+	return ScanMulti(r, []Target{{Column: column, Numeric: numeric}}, func(_ int, e Entry, err error) error {
+		if err != nil {
+			return err
+		}
+		return fn(e)
+	})
+}
+
+// ScanMulti streams r exactly once, extracting the column described by
+// each of targets from every record line and calling fn with the target's
+// index into targets, the resulting Entry, and any validation error for
+// that target on that line. This lets several indexes over distinct
+// columns of the same large source file be built from a single pass,
+// rather than re-reading it once per column.
+//
+// Targets are validated independently: a column that's invalid for one
+// target doesn't prevent fn from seeing the other targets' (valid) Entry
+// values for the same line. fn decides what a validation error means for
+// its target — returning nil to skip that target for that line and keep
+// scanning, or returning the error (or any other error) to abort the
+// entire scan for every target.
+func ScanMulti(r io.Reader, targets []Target, fn func(target int, e Entry, err error) error) error {
+	for i, t := range targets {
+		if t.Column < 1 || t.Column > 9 {
+			return fmt.Errorf("target %d: columns are numbered starting from 1 to a maximum of 9, got %d", i, t.Column)
+		}
+	}
+
+	br := bufio.NewReader(r)
+
+	var offset int64
+	var lineNumber int
+	for {
+		lineOffset := offset
+		raw, readErr := br.ReadString('\n')
+		offset += int64(len(raw))
+		if readErr != nil && readErr != io.EOF {
+			return fmt.Errorf("failed to read source: %w", readErr)
+		}
+		eof := readErr == io.EOF
+		if eof && raw == "" {
+			return nil
+		}
+		lineNumber++
+
+		line := strings.TrimSpace(raw)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			columns := strings.Split(line, ":")
+			for i, t := range targets {
+				var entry Entry
+				var verr error
+				switch {
+				case t.Column > len(columns):
+					verr = fmt.Errorf("line %d (offset %d): target %d column %d exceeds the number of available columns (max %d)", lineNumber, lineOffset, i, t.Column, len(columns))
+				case t.Numeric && !reID.MatchString(columns[t.Column-1]):
+					verr = fmt.Errorf("line %d (offset %d): invalid numeric identifier %q", lineNumber, lineOffset, columns[t.Column-1])
+				case !t.Numeric && !reName.MatchString(columns[t.Column-1]):
+					verr = fmt.Errorf("line %d (offset %d): invalid user or group name %q", lineNumber, lineOffset, columns[t.Column-1])
+				default:
+					entry = Entry{Key: columns[t.Column-1], Offset: lineOffset}
+				}
+
+				if err := fn(i, entry, verr); err != nil {
+					return err
+				}
+			}
+		}
+
+		if eof {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package nsscacheindex
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestScanMultiIsolatesPerTargetValidation reproduces the motivating example
+// from the --config request: a passwd file indexed by name (column 1) and
+// uid (column 3) in the same pass, where one row's uid is malformed. The
+// name target should see every row, even the one the uid target rejects.
+func TestScanMultiIsolatesPerTargetValidation(t *testing.T) {
+	const source = "root:x:0:0::/root:/bin/bash\n" +
+		"bogus:x:nope:0::/home/bogus:/bin/sh\n" +
+		"daemon:x:1:1::/sbin:/usr/sbin/nologin\n"
+
+	targets := []Target{
+		{Column: 1, Numeric: false}, // name
+		{Column: 3, Numeric: true},  // uid
+	}
+
+	var names []string
+	var uids []string
+	var uidFailures int
+	err := ScanMulti(strings.NewReader(source), targets, func(target int, e Entry, verr error) error {
+		switch target {
+		case 0:
+			if verr != nil {
+				t.Fatalf("unexpected name validation error: %v", verr)
+			}
+			names = append(names, e.Key)
+		case 1:
+			if verr != nil {
+				uidFailures++
+				return nil
+			}
+			uids = append(uids, e.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ScanMulti: %v", err)
+	}
+
+	if want := []string{"root", "bogus", "daemon"}; !equalStrings(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+	if uidFailures != 1 {
+		t.Errorf("uidFailures = %d, want 1", uidFailures)
+	}
+	if want := []string{"0", "1"}; !equalStrings(uids, want) {
+		t.Errorf("uids = %v, want %v", uids, want)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package nsscacheindex
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// VerifyIndex validates that the libnss-cache index at dstPath was built
+// correctly from srcPath's given 1-indexed column, returning the number of
+// records verified. It checks that:
+//
+//   - every record is sorted by key;
+//   - every record is padded to the same width, including the trailing LF;
+//   - every stored offset points at the start of a line in srcPath whose
+//     indexed column matches the stored key; and
+//   - every non-comment line in srcPath is represented by exactly one
+//     record.
+//
+// VerifyIndex returns a descriptive error identifying the first mismatch
+// found; it does not attempt to report every problem in one pass.
+func VerifyIndex(dstPath, srcPath string, column int, numeric bool) (rows int, err error) {
+	idxBytes, err := os.ReadFile(dstPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read index file %s: %w", dstPath, err)
+	}
+
+	if len(idxBytes) > 0 && idxBytes[len(idxBytes)-1] != '\n' {
+		return 0, fmt.Errorf("index file %s: does not end with a trailing LF", dstPath)
+	}
+
+	var records [][]byte
+	if len(idxBytes) > 0 {
+		records = bytes.Split(idxBytes[:len(idxBytes)-1], []byte{'\n'})
+	}
+
+	width := -1
+	keyByOffset := make(map[int64]string, len(records))
+	var prevKey string
+	for i, rec := range records {
+		if width == -1 {
+			width = len(rec)
+		} else if len(rec) != width {
+			return 0, fmt.Errorf("index file %s: record %d has length %d, expected %d", dstPath, i, len(rec), width)
+		}
+
+		fields := bytes.SplitN(rec, []byte{0}, 3)
+		if len(fields) < 2 {
+			return 0, fmt.Errorf("index file %s: record %d is missing its NUL-delimited offset field", dstPath, i)
+		}
+		key := string(fields[0])
+
+		offset, convErr := strconv.ParseInt(string(fields[1]), 10, 64)
+		if convErr != nil {
+			return 0, fmt.Errorf("index file %s: record %d has invalid offset %q: %w", dstPath, i, fields[1], convErr)
+		}
+
+		if i > 0 && key < prevKey {
+			return 0, fmt.Errorf("index file %s: record %d key %q sorts before previous key %q", dstPath, i, key, prevKey)
+		}
+		prevKey = key
+
+		if _, dup := keyByOffset[offset]; dup {
+			return 0, fmt.Errorf("index file %s: offset %d is referenced by more than one record", dstPath, offset)
+		}
+		keyByOffset[offset] = key
+	}
+
+	src, err := os.OpenFile(srcPath, os.O_RDONLY, 0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open source file %s: %w", srcPath, err)
+	}
+	defer src.Close()
+
+	var sourceLines, matched int
+	err = Scan(src, column, numeric, func(e Entry) error {
+		sourceLines++
+		wantKey, ok := keyByOffset[e.Offset]
+		if !ok {
+			return fmt.Errorf("source line at offset %d (key %q) is missing from index file %s", e.Offset, e.Key, dstPath)
+		}
+		if wantKey != e.Key {
+			return fmt.Errorf("index file %s: record for offset %d has key %q but source file %s's column is %q", dstPath, e.Offset, wantKey, srcPath, e.Key)
+		}
+		matched++
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to verify against source file %s: %w", srcPath, err)
+	}
+
+	if matched != len(keyByOffset) {
+		return 0, fmt.Errorf("index file %s has %d records but only %d correspond to a line in source file %s; %d are stale", dstPath, len(keyByOffset), matched, srcPath, len(keyByOffset)-matched)
+	}
+	if sourceLines != len(keyByOffset) {
+		return 0, fmt.Errorf("source file %s has %d indexable lines but index file %s has %d records", srcPath, sourceLines, dstPath, len(keyByOffset))
+	}
+
+	return len(keyByOffset), nil
+}
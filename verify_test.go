@@ -0,0 +1,123 @@
+package nsscacheindex
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const verifyTestSource = "root:x:0:0::/root:/bin/bash\n" +
+	"daemon:x:1:1::/sbin:/usr/sbin/nologin\n" +
+	"games:x:5:60::/usr/games:/usr/sbin/nologin\n"
+
+// writeVerifyFixture writes src to a source file and idx to an index file
+// inside t.TempDir, returning both paths.
+func writeVerifyFixture(t *testing.T, src string, idx []byte) (srcPath, idxPath string) {
+	t.Helper()
+	dir := t.TempDir()
+	srcPath = filepath.Join(dir, "passwd")
+	idxPath = filepath.Join(dir, "passwd.ix")
+	if err := os.WriteFile(srcPath, []byte(src), 0o644); err != nil {
+		t.Fatalf("write source fixture: %v", err)
+	}
+	if err := os.WriteFile(idxPath, idx, 0o644); err != nil {
+		t.Fatalf("write index fixture: %v", err)
+	}
+	return srcPath, idxPath
+}
+
+// buildValidIndex indexes column 1 of verifyTestSource the same way
+// BuildFile would, returning the raw index bytes for tests to corrupt.
+func buildValidIndex(t *testing.T) []byte {
+	t.Helper()
+	b := NewBuilder(0)
+	defer b.Close()
+	if _, err := b.AddFromReader(strings.NewReader(verifyTestSource), 1, false); err != nil {
+		t.Fatalf("AddFromReader: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := b.WriteIndex(&buf); err != nil {
+		t.Fatalf("WriteIndex: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestVerifyIndexValid(t *testing.T) {
+	idx := buildValidIndex(t)
+	srcPath, idxPath := writeVerifyFixture(t, verifyTestSource, idx)
+
+	rows, err := VerifyIndex(idxPath, srcPath, 1, false)
+	if err != nil {
+		t.Fatalf("VerifyIndex: %v", err)
+	}
+	if rows != 3 {
+		t.Errorf("rows = %d, want 3", rows)
+	}
+}
+
+func TestVerifyIndexDetectsTruncatedTrailingLF(t *testing.T) {
+	idx := buildValidIndex(t)
+	corrupt := idx[:len(idx)-1] // drop the final record's trailing LF
+	srcPath, idxPath := writeVerifyFixture(t, verifyTestSource, corrupt)
+
+	_, err := VerifyIndex(idxPath, srcPath, 1, false)
+	if err == nil {
+		t.Fatal("VerifyIndex succeeded on an index missing its trailing LF")
+	}
+	if !strings.Contains(err.Error(), "trailing LF") {
+		t.Errorf("error = %v, want mention of trailing LF", err)
+	}
+}
+
+func TestVerifyIndexDetectsUnsortedKeys(t *testing.T) {
+	idx := buildValidIndex(t)
+	records := bytes.Split(idx[:len(idx)-1], []byte{'\n'})
+	if len(records) < 2 {
+		t.Fatalf("fixture produced only %d records", len(records))
+	}
+	records[0], records[1] = records[1], records[0]
+	corrupt := append(bytes.Join(records, []byte{'\n'}), '\n')
+	srcPath, idxPath := writeVerifyFixture(t, verifyTestSource, corrupt)
+
+	_, err := VerifyIndex(idxPath, srcPath, 1, false)
+	if err == nil {
+		t.Fatal("VerifyIndex succeeded on an index with swapped (unsorted) records")
+	}
+	if !strings.Contains(err.Error(), "sorts before") {
+		t.Errorf("error = %v, want mention of sort order", err)
+	}
+}
+
+func TestVerifyIndexDetectsInconsistentWidth(t *testing.T) {
+	idx := buildValidIndex(t)
+	records := bytes.Split(idx[:len(idx)-1], []byte{'\n'})
+	records[0] = append(records[0], 0) // widen one record relative to the rest
+	corrupt := append(bytes.Join(records, []byte{'\n'}), '\n')
+	srcPath, idxPath := writeVerifyFixture(t, verifyTestSource, corrupt)
+
+	_, err := VerifyIndex(idxPath, srcPath, 1, false)
+	if err == nil {
+		t.Fatal("VerifyIndex succeeded on an index with an inconsistent record width")
+	}
+	if !strings.Contains(err.Error(), "length") {
+		t.Errorf("error = %v, want mention of record length", err)
+	}
+}
+
+func TestVerifyIndexDetectsMissingRecord(t *testing.T) {
+	idx := buildValidIndex(t)
+	records := bytes.Split(idx[:len(idx)-1], []byte{'\n'})
+	records = records[1:] // drop the first record entirely
+	corrupt := append(bytes.Join(records, []byte{'\n'}), '\n')
+	srcPath, idxPath := writeVerifyFixture(t, verifyTestSource, corrupt)
+
+	_, err := VerifyIndex(idxPath, srcPath, 1, false)
+	if err == nil {
+		t.Fatal("VerifyIndex succeeded on an index missing a record for a source line")
+	}
+	if !strings.Contains(err.Error(), "missing from index") {
+		t.Errorf("error = %v, want mention of the missing record", err)
+	}
+}
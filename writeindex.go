@@ -0,0 +1,60 @@
+package nsscacheindex
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+)
+
+// WriteIndex writes entries to w using libnss-cache's on-disk format: each
+// record is "key\x00offset\x00", zero-padded to the width of the widest
+// record, followed by a trailing LF. entries must already be sorted by Key;
+// WriteIndex does not sort them.
+func WriteIndex(w io.Writer, entries []Entry) error {
+	longestEntryLength := 2
+	for _, e := range entries {
+		if el := entryLength(e); el > longestEntryLength {
+			longestEntryLength = el
+		}
+	}
+	return writeEntries(w, entries, longestEntryLength)
+}
+
+// entryLength returns the on-disk length of e's key and offset fields,
+// including their two NUL terminators but excluding padding and the
+// trailing LF.
+func entryLength(e Entry) int {
+	return 2 + len(e.Key) + len(strconv.FormatInt(e.Offset, 10))
+}
+
+// writeEntries writes entries to w padded to width, which must already be
+// at least as large as entryLength(e) for every e in entries. Unlike
+// WriteIndex, it does not compute width itself, so that callers merging
+// several batches can pad every batch to a width known in advance.
+func writeEntries(w io.Writer, entries []Entry, width int) error {
+	var buf bytes.Buffer
+	buf.Grow(width + 1)
+	for _, e := range entries {
+		offset := strconv.FormatInt(e.Offset, 10)
+
+		buf.WriteString(e.Key)
+		buf.WriteByte(0)
+		buf.WriteString(offset)
+		buf.WriteByte(0)
+
+		pad := width - entryLength(e)
+		for pad > 0 {
+			buf.WriteByte(0)
+			pad--
+		}
+
+		buf.WriteByte('\n')
+
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+		buf.Reset()
+	}
+
+	return nil
+}